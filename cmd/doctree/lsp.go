@@ -0,0 +1,501 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hexops/cmder"
+	"github.com/pkg/errors"
+
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func init() {
+	const usage = `
+Examples:
+
+  Speak LSP over stdio, for use from an editor (VS Code, Neovim, Helix, ...):
+
+    $ doctree lsp -project github.com/sourcegraph/doctree
+
+`
+
+	flagSet := flag.NewFlagSet("lsp", flag.ExitOnError)
+	dataDirFlag := flagSet.String("data-dir", defaultDataDir(), "where doctree stores its data")
+	projectNameFlag := flagSet.String("project", "", "the doctree project to serve docs for")
+
+	handler := func(args []string) error {
+		_ = flagSet.Parse(args)
+
+		ctx := context.Background()
+		indexDataDir := filepath.Join(*dataDirFlag, "index")
+
+		server, err := newLSPServer(ctx, indexDataDir, *projectNameFlag)
+		if err != nil {
+			return errors.Wrap(err, "newLSPServer")
+		}
+		return server.Serve(os.Stdin, os.Stdout)
+	}
+
+	commands = append(commands, &cmder.Command{
+		FlagSet: flagSet,
+		Aliases: []string{},
+		Handler: handler,
+		UsageFunc: func() {
+			fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'doctree %s':\n", flagSet.Name())
+			flagSet.PrintDefaults()
+			fmt.Fprintf(flag.CommandLine.Output(), "%s", usage)
+		},
+	})
+}
+
+// lspServer serves a minimal subset of the Language Server Protocol (hover,
+// documentSymbol, workspaceSymbol, definition) sourced from the doctree indexes already
+// built for a project, so editors get doctree's docs without going through the HTTP UI.
+type lspServer struct {
+	ctx         context.Context
+	dataDir     string
+	project     string
+	pagesByPath map[string]schema.Page // relative page path -> page, across every indexed language
+	docs        map[string]string      // open document URI -> current text
+}
+
+func newLSPServer(ctx context.Context, dataDir, project string) (*lspServer, error) {
+	s := &lspServer{
+		ctx:         ctx,
+		dataDir:     dataDir,
+		project:     project,
+		pagesByPath: map[string]schema.Page{},
+		docs:        map[string]string{},
+	}
+
+	languages, err := indexer.Languages(ctx, dataDir, project)
+	if err != nil {
+		return nil, errors.Wrap(err, "Languages")
+	}
+	for _, language := range languages {
+		idx, err := indexer.Get(ctx, dataDir, project, language)
+		if err != nil {
+			return nil, errors.Wrap(err, "Get")
+		}
+		for _, lib := range idx.Libraries {
+			for _, page := range lib.Pages {
+				s.pagesByPath[page.Path] = page
+			}
+		}
+	}
+	return s, nil
+}
+
+// Serve reads LSP-framed JSON-RPC requests from r and writes responses/notifications to w
+// until r is closed or an exit notification is received.
+func (s *lspServer) Serve(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	for {
+		msg, err := readMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "readMessage")
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal(msg, &req); err != nil {
+			return errors.Wrap(err, "unmarshal request")
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, rpcErr := s.dispatch(req.Method, req.Params)
+		if req.ID == nil {
+			continue // notification; no response expected
+		}
+		if err := writeMessage(w, rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return errors.Wrap(err, "writeMessage")
+		}
+	}
+}
+
+func (s *lspServer) dispatch(method string, params json.RawMessage) (interface{}, *rpcError) {
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"hoverProvider":           true,
+				"documentSymbolProvider":  true,
+				"workspaceSymbolProvider": true,
+				"definitionProvider":      true,
+				"textDocumentSync":        1, // full document sync
+			},
+		}, nil
+	case "initialized", "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, internalError(err)
+		}
+		s.docs[p.TextDocument.URI] = p.TextDocument.Text
+		return nil, nil
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, internalError(err)
+		}
+		if len(p.ContentChanges) > 0 {
+			s.docs[p.TextDocument.URI] = p.ContentChanges[len(p.ContentChanges)-1].Text
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, internalError(err)
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return nil, nil
+	case "textDocument/hover":
+		var p textDocumentPositionParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, internalError(err)
+		}
+		return s.hover(p), nil
+	case "textDocument/documentSymbol":
+		var p documentSymbolParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, internalError(err)
+		}
+		return s.documentSymbol(p), nil
+	case "textDocument/definition":
+		var p textDocumentPositionParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, internalError(err)
+		}
+		return s.definition(p), nil
+	case "workspace/symbol":
+		var p workspaceSymbolParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, internalError(err)
+		}
+		return s.workspaceSymbol(p)
+	default:
+		return nil, nil // unhandled notifications/requests are silently ignored
+	}
+}
+
+func (s *lspServer) hover(p textDocumentPositionParams) *hoverResult {
+	word := wordAt(s.docs[p.TextDocument.URI], p.Position)
+	if word == "" {
+		return nil
+	}
+	page, section := s.findSymbol(uriPath(p.TextDocument.URI), word)
+	if section == nil {
+		return nil
+	}
+	_ = page
+	return &hoverResult{
+		Contents: markupContent{Kind: "markdown", Value: string(section.Label) + "\n\n" + string(section.Detail)},
+	}
+}
+
+func (s *lspServer) documentSymbol(p documentSymbolParams) []documentSymbol {
+	page, ok := s.pagesByPath[uriPath(p.TextDocument.URI)]
+	if !ok {
+		return nil
+	}
+	var out []documentSymbol
+	for _, section := range page.Sections {
+		out = append(out, sectionToSymbol(section))
+	}
+	return out
+}
+
+func sectionToSymbol(section schema.Section) documentSymbol {
+	kind := symbolKindFunction
+	if section.Category {
+		kind = symbolKindNamespace
+	}
+	sym := documentSymbol{
+		Name:           section.ShortLabel,
+		Detail:         string(section.Label),
+		Kind:           kind,
+		Range:          zeroRange,
+		SelectionRange: zeroRange,
+	}
+	for _, child := range section.Children {
+		sym.Children = append(sym.Children, sectionToSymbol(child))
+	}
+	return sym
+}
+
+func (s *lspServer) definition(p textDocumentPositionParams) []location {
+	word := wordAt(s.docs[p.TextDocument.URI], p.Position)
+	if word == "" {
+		return nil
+	}
+	page, section := s.findSymbol(uriPath(p.TextDocument.URI), word)
+	if section == nil {
+		return nil
+	}
+	return []location{{URI: pathToURI(page.Path), Range: zeroRange}}
+}
+
+func (s *lspServer) workspaceSymbol(p workspaceSymbolParams) ([]symbolInformation, *rpcError) {
+	results, err := searchIndex(s.ctx, s.dataDir, p.Query, s.project)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	out := make([]symbolInformation, 0, len(results))
+	for _, result := range results {
+		out = append(out, symbolInformation{
+			Name: result.Label,
+			Kind: symbolKindFunction,
+			Location: location{
+				URI:   pathToURI(result.PagePath),
+				Range: zeroRange,
+			},
+		})
+	}
+	return out, nil
+}
+
+// findSymbol looks for a section named word, preferring the page at relPath (the file the
+// hover/definition request came from) before falling back to every other indexed page. The
+// fallback visits pages in a deterministic (sorted-by-path) order so that an ambiguous word
+// (one that names a section on more than one page, e.g. "__init__") resolves to the same
+// page every time instead of whatever Go's randomized map iteration lands on.
+func (s *lspServer) findSymbol(relPath, word string) (schema.Page, *schema.Section) {
+	if page, ok := s.pagesByPath[relPath]; ok {
+		if section := findSectionByLabel(page.Sections, word); section != nil {
+			return page, section
+		}
+	}
+
+	paths := make([]string, 0, len(s.pagesByPath))
+	for path := range s.pagesByPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		page := s.pagesByPath[path]
+		if section := findSectionByLabel(page.Sections, word); section != nil {
+			return page, section
+		}
+	}
+	return schema.Page{}, nil
+}
+
+// findSectionByLabel finds the first section named word, depth-first. Category sections
+// (the synthetic "func"/"class" grouping wrappers) are skipped: they're not symbols a user
+// can hover or jump to, and matching them would make hovering the literal word "func" or
+// "class" spuriously resolve to one.
+func findSectionByLabel(sections []schema.Section, word string) *schema.Section {
+	for i := range sections {
+		if !sections[i].Category && (sections[i].ShortLabel == word || sections[i].ID == word) {
+			return &sections[i]
+		}
+		if found := findSectionByLabel(sections[i].Children, word); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// wordAt returns the identifier under position in text, or "" if there isn't one.
+func wordAt(text string, pos position) string {
+	lines := strings.Split(text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return ""
+	}
+	line := lines[pos.Line]
+	runes := []rune(line)
+	if pos.Character < 0 || pos.Character > len(runes) {
+		return ""
+	}
+
+	isIdent := func(r rune) bool {
+		return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+	}
+
+	start, end := pos.Character, pos.Character
+	for start > 0 && isIdent(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isIdent(runes[end]) {
+		end++
+	}
+	if start == end {
+		return ""
+	}
+	return string(runes[start:end])
+}
+
+// uriPath turns a file:// URI into the workspace-relative path doctree indexes under.
+func uriPath(uri string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(uri, "file://"), "/")
+}
+
+func pathToURI(path string) string {
+	return "file://" + path
+}
+
+var zeroRange = rangeT{Start: position{0, 0}, End: position{0, 0}}
+
+const (
+	symbolKindFunction  = 12
+	symbolKindNamespace = 3
+)
+
+// --- minimal LSP wire types (only the fields doctree's handlers need) ---
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func internalError(err error) *rpcError {
+	return &rpcError{Code: -32603, Message: err.Error()}
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type rangeT struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string `json:"uri"`
+	Range rangeT `json:"range"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+type documentSymbolParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type workspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+type didOpenParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hoverResult struct {
+	Contents markupContent `json:"contents"`
+}
+
+type documentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          rangeT           `json:"range"`
+	SelectionRange rangeT           `json:"selectionRange"`
+	Children       []documentSymbol `json:"children,omitempty"`
+}
+
+type symbolInformation struct {
+	Name     string   `json:"name"`
+	Kind     int      `json:"kind"`
+	Location location `json:"location"`
+}
+
+// --- LSP stdio framing (Content-Length headers, as defined by the LSP spec) ---
+
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	var contentLength int
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break // blank line ends the header section
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			contentLength, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, errors.Wrap(err, "parse Content-Length")
+			}
+		}
+	}
+	if contentLength == 0 {
+		return nil, errors.New("lsp: missing Content-Length header")
+	}
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func writeMessage(w io.Writer, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
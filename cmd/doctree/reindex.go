@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/hexops/cmder"
+	"github.com/pkg/errors"
+
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/indexer/trigram"
+)
+
+func init() {
+	const usage = `
+Examples:
+
+  Rebuild the trigram search index for every indexed project:
+
+    $ doctree reindex --trigrams
+
+`
+
+	// Parse flags for our subcommand.
+	flagSet := flag.NewFlagSet("reindex", flag.ExitOnError)
+	dataDirFlag := flagSet.String("data-dir", defaultDataDir(), "where doctree stores its data")
+	projectNameFlag := flagSet.String("project", "", "only reindex a specific project")
+	trigramsFlag := flagSet.Bool("trigrams", false, "rebuild the on-disk trigram search index")
+
+	// Handles calls to our subcommand.
+	handler := func(args []string) error {
+		_ = flagSet.Parse(args)
+		if !*trigramsFlag {
+			return &cmder.UsageError{}
+		}
+
+		ctx := context.Background()
+		indexDataDir := filepath.Join(*dataDirFlag, "index")
+
+		projects := []string{*projectNameFlag}
+		if *projectNameFlag == "" {
+			var err error
+			projects, err = indexer.List(ctx, indexDataDir)
+			if err != nil {
+				return errors.Wrap(err, "List")
+			}
+		}
+
+		for _, project := range projects {
+			languages, err := indexer.Languages(ctx, indexDataDir, project)
+			if err != nil {
+				return errors.Wrap(err, "Languages")
+			}
+
+			idx := trigram.New()
+			for _, language := range languages {
+				langIndex, err := indexer.Get(ctx, indexDataDir, project, language)
+				if err != nil {
+					return errors.Wrap(err, "Get")
+				}
+				for _, doc := range trigram.BuildProjectIndex(project, langIndex).Documents() {
+					idx.Add(doc)
+				}
+			}
+
+			if err := idx.Write(trigram.Path(indexDataDir, project)); err != nil {
+				return errors.Wrap(err, "Write")
+			}
+			fmt.Printf("doctree: reindexed trigrams for %q\n", project)
+		}
+		return nil
+	}
+
+	// Register the command.
+	commands = append(commands, &cmder.Command{
+		FlagSet: flagSet,
+		Aliases: []string{},
+		Handler: handler,
+		UsageFunc: func() {
+			fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'doctree %s':\n", flagSet.Name())
+			flagSet.PrintDefaults()
+			fmt.Fprintf(flag.CommandLine.Output(), "%s", usage)
+		},
+	})
+}
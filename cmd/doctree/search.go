@@ -2,9 +2,16 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"runtime"
+	"strings"
 
 	"github.com/hexops/cmder"
 	"github.com/pkg/errors"
@@ -14,22 +21,48 @@ import (
 	_ "github.com/sourcegraph/doctree/doctree/indexer/golang"
 	_ "github.com/sourcegraph/doctree/doctree/indexer/markdown"
 	_ "github.com/sourcegraph/doctree/doctree/indexer/python"
+	"github.com/sourcegraph/doctree/doctree/indexer/trigram"
+)
+
+// ANSI escape codes used to render search results; kept minimal so we don't need a color
+// library dependency for a handful of styled spans.
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiFaint     = "\x1b[2m"
+	ansiCyan      = "\x1b[36m"
+	ansiHighlight = "\x1b[1;33m" // bold yellow, used to highlight the matched query in a snippet
 )
 
 func init() {
 	const usage = `
 Examples:
 
-  Search :
+  Search:
 
     $ doctree search 'myquery'
 
+  Search a specific project, limit to 5 results, and emit JSON:
+
+    $ doctree search -project github.com/sourcegraph/doctree -format json -limit 5 'myquery'
+
+  Search and open the first result in the doctree UI:
+
+    $ doctree search -open 'myquery'
+
+  Search with a regular expression (requires a trigram index, see 'doctree reindex -trigrams'):
+
+    $ doctree search '/^func Parse.*Error$/'
+
 `
 
 	// Parse flags for our subcommand.
 	flagSet := flag.NewFlagSet("search", flag.ExitOnError)
 	dataDirFlag := flagSet.String("data-dir", defaultDataDir(), "where doctree stores its data")
 	projectNameFlag := flagSet.String("project", "", "search in a specific project")
+	formatFlag := flagSet.String("format", "text", "output format: text|json|jsonl")
+	limitFlag := flagSet.Int("limit", 20, "maximum number of results to show")
+	openFlag := flagSet.Bool("open", false, "open the first result in the doctree UI")
 
 	// Handles calls to our subcommand.
 	handler := func(args []string) error {
@@ -41,12 +74,38 @@ Examples:
 
 		ctx := context.Background()
 		indexDataDir := filepath.Join(*dataDirFlag, "index")
-		_, err := indexer.Search(ctx, indexDataDir, query, *projectNameFlag)
+		results, err := searchIndex(ctx, indexDataDir, query, *projectNameFlag)
 		if err != nil {
 			return errors.Wrap(err, "Search")
 		}
 
-		// TODO: CLI interface for search! Print the results here at least :)
+		if *limitFlag > 0 && len(results) > *limitFlag {
+			results = results[:*limitFlag]
+		}
+
+		switch *formatFlag {
+		case "text":
+			renderText(os.Stdout, results, highlighter(query))
+		case "json":
+			if err := json.NewEncoder(os.Stdout).Encode(results); err != nil {
+				return errors.Wrap(err, "encode json")
+			}
+		case "jsonl":
+			enc := json.NewEncoder(os.Stdout)
+			for _, result := range results {
+				if err := enc.Encode(result); err != nil {
+					return errors.Wrap(err, "encode jsonl")
+				}
+			}
+		default:
+			return errors.Errorf("unrecognized -format %q (want text, json, or jsonl)", *formatFlag)
+		}
+
+		if *openFlag && len(results) > 0 {
+			if err := openBrowser(deepLink(results[0])); err != nil {
+				return errors.Wrap(err, "open")
+			}
+		}
 		return nil
 	}
 
@@ -62,3 +121,219 @@ Examples:
 		},
 	})
 }
+
+// searchIndex finds matches for query, preferring each project's on-disk trigram index
+// (built by `doctree reindex -trigrams`) over indexer.Search's linear scan. A project that
+// doesn't have a trigram index yet falls back to indexer.Search so results don't silently
+// go missing just because reindex hasn't been run for it.
+//
+// A query of the form `/pattern/` is treated as a regular expression: pattern is compiled
+// and matched against each candidate document's full content, with the trigram index used
+// only to prefilter candidates via the literal runs in pattern (see SearchRegexp). Regex
+// queries require a trigram index; a project without one is skipped rather than silently
+// falling back to a substring scan of the raw `/pattern/` text.
+func searchIndex(ctx context.Context, dataDir, query, project string) ([]indexer.SearchResult, error) {
+	projects := []string{project}
+	if project == "" {
+		var err error
+		projects, err = indexer.List(ctx, dataDir)
+		if err != nil {
+			return nil, errors.Wrap(err, "List")
+		}
+	}
+
+	pattern, isRegexp := parseRegexpQuery(query)
+	var re *regexp.Regexp
+	var parsed *syntax.Regexp
+	if isRegexp {
+		var err error
+		re, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid regexp %q", pattern)
+		}
+		parsed, err = syntax.Parse(pattern, syntax.Perl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid regexp %q", pattern)
+		}
+	}
+
+	var results []indexer.SearchResult
+	for _, p := range projects {
+		idx, err := trigram.Open(trigram.Path(dataDir, p))
+		if err != nil {
+			if isRegexp {
+				// No trigram index to prefilter candidates against, and a plain
+				// substring scan of the raw `/pattern/` text would almost never be
+				// what the user meant. Skip this project rather than return noise.
+				continue
+			}
+			scanned, err := indexer.Search(ctx, dataDir, query, p)
+			if err != nil {
+				return nil, errors.Wrap(err, "Search")
+			}
+			results = append(results, scanned...)
+			continue
+		}
+
+		var docs []trigram.Document
+		if isRegexp {
+			docs = idx.SearchRegexp(parsed, re.MatchString)
+		} else {
+			docs = idx.Search(query)
+		}
+		for _, doc := range docs {
+			results = append(results, indexer.SearchResult{
+				ProjectName: doc.Project,
+				Language:    doc.Language,
+				PagePath:    doc.PagePath,
+				SectionID:   doc.SectionID,
+				Label:       doc.Label,
+				Snippet:     doc.Content,
+			})
+		}
+	}
+	return results, nil
+}
+
+// parseRegexpQuery reports whether query uses the `/pattern/` regex syntax and, if so,
+// returns the pattern with its delimiting slashes stripped.
+func parseRegexpQuery(query string) (pattern string, ok bool) {
+	if len(query) < 2 || query[0] != '/' || query[len(query)-1] != '/' {
+		return "", false
+	}
+	return query[1 : len(query)-1], true
+}
+
+// renderText writes results to w grouped by project, with a colorized header per project
+// and a truncated, highlighted snippet per result. highlight is applied to each snippet
+// after truncation to mark the part of it that matched the query.
+func renderText(w *os.File, results []indexer.SearchResult, highlight func(string) string) {
+	var lastProject, lastLanguage string
+	for _, result := range results {
+		if result.ProjectName != lastProject || result.Language != lastLanguage {
+			fmt.Fprintf(w, "%s%s%s %s(%s)%s\n", ansiBold, result.ProjectName, ansiReset, ansiFaint, result.Language, ansiReset)
+			lastProject, lastLanguage = result.ProjectName, result.Language
+		}
+
+		fmt.Fprintf(w, "  %s%s%s\n", ansiCyan, result.Label, ansiReset)
+		if snippet := highlight(truncateSnippet(result.Snippet, 160)); snippet != "" {
+			fmt.Fprintf(w, "    %s\n", snippet)
+		}
+		fmt.Fprintf(w, "    %s%s#%s%s\n", ansiFaint, result.PagePath, result.SectionID, ansiReset)
+	}
+}
+
+// highlighter returns the highlight function renderText should use for query. A `/pattern/`
+// regex query is matched as a regex (the literal slashes and metacharacters practically
+// never occur verbatim in a snippet, so comparing against the raw query string would never
+// highlight anything); any other query is matched as a literal case-insensitive substring.
+// An invalid regex falls back to highlighting nothing rather than failing the whole search,
+// since searchIndex already reports that error before renderText is ever reached.
+func highlighter(query string) func(string) string {
+	if pattern, ok := parseRegexpQuery(query); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return func(s string) string { return s }
+		}
+		return func(s string) string { return highlightRegexp(s, re) }
+	}
+	return func(s string) string { return highlight(s, query) }
+}
+
+// truncateSnippet trims s to at most maxLen runes, preferring a clean cut at a word
+// boundary and appending an ellipsis when anything was cut.
+func truncateSnippet(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	cut := string(runes[:maxLen])
+	if idx := strings.LastIndexAny(cut, " \t\n"); idx > 0 {
+		cut = cut[:idx]
+	}
+	return cut + "…"
+}
+
+// highlight wraps every case-insensitive occurrence of query in s with the highlight
+// ANSI codes. Matching is done on runes rather than bytes, since lower-casing a rune can
+// change how many UTF-8 bytes it takes (e.g. 'K' U+212A -> 'k'), which would otherwise
+// desync byte offsets found in a lowercased copy from offsets in the original string.
+func highlight(s, query string) string {
+	if query == "" {
+		return s
+	}
+	runes := []rune(s)
+	lowerRunes := []rune(strings.ToLower(s))
+	lowerQuery := []rune(strings.ToLower(query))
+	n := len(lowerQuery)
+	if n == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	i := 0
+	for i < len(runes) {
+		if i+n <= len(lowerRunes) && runesEqual(lowerRunes[i:i+n], lowerQuery) {
+			b.WriteString(ansiHighlight)
+			b.WriteString(string(runes[i : i+n]))
+			b.WriteString(ansiReset)
+			i += n
+			continue
+		}
+		b.WriteRune(runes[i])
+		i++
+	}
+	return b.String()
+}
+
+// highlightRegexp wraps every match of re in s with the highlight ANSI codes.
+func highlightRegexp(s string, re *regexp.Regexp) string {
+	locs := re.FindAllStringIndex(s, -1)
+	if len(locs) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range locs {
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(ansiHighlight)
+		b.WriteString(s[loc[0]:loc[1]])
+		b.WriteString(ansiReset)
+		last = loc[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func runesEqual(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// deepLink returns the doctree HTTP UI URL for a single search result.
+func deepLink(result indexer.SearchResult) string {
+	return fmt.Sprintf("http://localhost:3333/%s/-/%s/%s#%s", result.ProjectName, result.Language, result.PagePath, result.SectionID)
+}
+
+// openBrowser launches the user's default browser at url.
+func openBrowser(url string) error {
+	var cmd string
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		cmd, args = "open", []string{url}
+	case "windows":
+		cmd, args = "cmd", []string{"/c", "start", url}
+	default:
+		cmd, args = "xdg-open", []string{url}
+	}
+	return exec.Command(cmd, args...).Start()
+}
@@ -0,0 +1,417 @@
+// Package docstring parses Python docstrings into structured sections. It understands
+// the three conventions in common use — Google, NumPy, and Sphinx/reST — so that
+// `pythonIndexer.IndexDir` can surface per-parameter docs, a returns/raises summary, and
+// runnable examples instead of dumping the whole docstring as opaque Markdown.
+package docstring
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Param is one documented parameter, pulled out of an Args/Parameters/:param: section.
+type Param struct {
+	Name        string
+	Description string
+}
+
+// Raises is one documented exception, pulled out of a Raises/:raises: section.
+type Raises struct {
+	Type        string
+	Description string
+}
+
+// Example is a documented usage example. Doctest examples (">>> " prompts) are kept
+// separate from prose examples so callers can render them as runnable child sections,
+// the way Go's godoc surfaces Example* functions.
+type Example struct {
+	Text      string
+	IsDoctest bool
+}
+
+// Parsed is a docstring broken down into its conventional fields. Any field the source
+// docstring didn't use is left zero.
+type Parsed struct {
+	Summary     string
+	Description string
+	Params      []Param
+	Returns     string
+	Raises      []Raises
+	Examples    []Example
+	SeeAlso     string
+}
+
+var (
+	numpyUnderline  = regexp.MustCompile(`^-{3,}\s*$`)
+	sphinxParam     = regexp.MustCompile(`^:param\s+(?:(\S+)\s+)?(\S+):\s*(.*)$`)
+	sphinxType      = regexp.MustCompile(`^:type\s+(\S+):\s*(.*)$`)
+	sphinxReturns   = regexp.MustCompile(`^:returns?:\s*(.*)$`)
+	sphinxRaises    = regexp.MustCompile(`^:raises?(?:\s+(\S+))?:\s*(.*)$`)
+	googleParamLine = regexp.MustCompile(`^(\s*)(\*{0,2}\w+)\s*(?:\(([^)]*)\))?:\s*(.*)$`)
+	doctestPrompt   = regexp.MustCompile(`^(\s*)>>>\s?(.*)$`)
+	// numpyParamHeader matches a NumPy "name : type" parameter header. The NumPy style
+	// guide requires a space on both sides of the colon, which is what tells a header
+	// apart from a plain continuation line that happens to contain a colon (e.g. "a
+	// mapping of key: value" has no space before its colon, so it isn't a header).
+	numpyParamHeader = regexp.MustCompile(`^(\S.*?)\s+:\s+(.*)$`)
+)
+
+var googleSections = map[string]string{
+	"args":       "params",
+	"arguments":  "params",
+	"parameters": "params",
+	"returns":    "returns",
+	"return":     "returns",
+	"raises":     "raises",
+	"exceptions": "raises",
+	"examples":   "examples",
+	"example":    "examples",
+	"see also":   "seealso",
+}
+
+// Parse detects the docstring's convention and extracts its structured fields. raw should
+// already have the enclosing triple quotes stripped.
+func Parse(raw string) Parsed {
+	lines := splitDedent(raw)
+
+	summaryEnd := 0
+	for summaryEnd < len(lines) && strings.TrimSpace(lines[summaryEnd]) != "" {
+		summaryEnd++
+	}
+	summary := strings.TrimSpace(strings.Join(lines[:summaryEnd], " "))
+
+	rest := lines[summaryEnd:]
+	for len(rest) > 0 && strings.TrimSpace(rest[0]) == "" {
+		rest = rest[1:]
+	}
+
+	switch {
+	case hasSphinxDirectives(rest):
+		return parseSphinx(summary, rest)
+	case hasNumPyHeaders(rest):
+		return parseNumPy(summary, rest)
+	default:
+		return parseGoogle(summary, rest)
+	}
+}
+
+func hasSphinxDirectives(lines []string) bool {
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		if strings.HasPrefix(t, ":param") || strings.HasPrefix(t, ":returns") || strings.HasPrefix(t, ":return:") || strings.HasPrefix(t, ":raises") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasNumPyHeaders(lines []string) bool {
+	for i := 1; i < len(lines); i++ {
+		header := strings.ToLower(strings.TrimSpace(lines[i-1]))
+		if _, ok := googleSections[header]; ok && numpyUnderline.MatchString(lines[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGoogle handles the Google style, e.g.:
+//
+//	Args:
+//	    name (str): description
+//	Returns:
+//	    bool: description
+func parseGoogle(summary string, lines []string) Parsed {
+	p := Parsed{Summary: summary}
+	var description []string
+	var section string
+
+	flushExamples := func(buf []string) {
+		p.Examples = append(p.Examples, collectExamples(buf)...)
+	}
+
+	var sectionBuf []string
+	closeSection := func() {
+		switch section {
+		case "params":
+			p.Params = append(p.Params, parseGoogleParams(sectionBuf)...)
+		case "returns":
+			p.Returns = strings.TrimSpace(strings.Join(sectionBuf, " "))
+		case "raises":
+			p.Raises = append(p.Raises, parseGoogleParams(sectionBuf).toRaises()...)
+		case "examples":
+			flushExamples(sectionBuf)
+		case "seealso":
+			p.SeeAlso = strings.TrimSpace(strings.Join(sectionBuf, " "))
+		}
+		sectionBuf = nil
+	}
+
+	for _, line := range lines {
+		if kind, ok := googleSections[strings.ToLower(strings.TrimSuffix(strings.TrimSpace(line), ":"))]; ok && strings.HasSuffix(strings.TrimSpace(line), ":") {
+			closeSection()
+			section = kind
+			continue
+		}
+		if section == "" {
+			description = append(description, line)
+			continue
+		}
+		sectionBuf = append(sectionBuf, line)
+	}
+	closeSection()
+
+	p.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return p
+}
+
+// paramList is a helper so closeSection can reuse the same "Name: description" parser for
+// both Args and Raises (the latter has a type instead of a name, converted by toRaises).
+type paramList []Param
+
+func (ps paramList) toRaises() []Raises {
+	out := make([]Raises, 0, len(ps))
+	for _, p := range ps {
+		out = append(out, Raises{Type: p.Name, Description: p.Description})
+	}
+	return out
+}
+
+func parseGoogleParams(lines []string) paramList {
+	var params paramList
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m := googleParamLine.FindStringSubmatch(line)
+		if m == nil {
+			// Continuation of the previous parameter's description.
+			if len(params) > 0 {
+				params[len(params)-1].Description = strings.TrimSpace(params[len(params)-1].Description + " " + strings.TrimSpace(line))
+			}
+			continue
+		}
+		params = append(params, Param{Name: m[2], Description: strings.TrimSpace(m[4])})
+	}
+	return params
+}
+
+// parseNumPy handles the NumPy style, e.g.:
+//
+//	Parameters
+//	----------
+//	name : str
+//	    description
+func parseNumPy(summary string, lines []string) Parsed {
+	p := Parsed{Summary: summary}
+	var description []string
+	var section string
+	var sectionBuf []string
+
+	closeSection := func() {
+		switch section {
+		case "params":
+			p.Params = append(p.Params, parseNumPyParams(sectionBuf)...)
+		case "returns":
+			p.Returns = strings.TrimSpace(strings.Join(sectionBuf, " "))
+		case "raises":
+			p.Raises = append(p.Raises, parseNumPyParams(sectionBuf).toRaises()...)
+		case "examples":
+			p.Examples = append(p.Examples, collectExamples(sectionBuf)...)
+		case "seealso":
+			p.SeeAlso = strings.TrimSpace(strings.Join(sectionBuf, " "))
+		}
+		sectionBuf = nil
+	}
+
+	i := 0
+	for i < len(lines) {
+		if i+1 < len(lines) && numpyUnderline.MatchString(lines[i+1]) {
+			if kind, ok := googleSections[strings.ToLower(strings.TrimSpace(lines[i]))]; ok {
+				closeSection()
+				section = kind
+				i += 2
+				continue
+			}
+		}
+		if section == "" {
+			description = append(description, lines[i])
+		} else {
+			sectionBuf = append(sectionBuf, lines[i])
+		}
+		i++
+	}
+	closeSection()
+
+	p.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return p
+}
+
+func parseNumPyParams(lines []string) paramList {
+	var params paramList
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if m := numpyParamHeader.FindStringSubmatch(line); m != nil && !strings.HasPrefix(line, " ") {
+			params = append(params, Param{Name: strings.TrimSpace(m[1])})
+			continue
+		}
+		if len(params) > 0 {
+			params[len(params)-1].Description = strings.TrimSpace(params[len(params)-1].Description + " " + strings.TrimSpace(line))
+		}
+	}
+	return params
+}
+
+// parseSphinx handles the Sphinx/reST style, e.g.:
+//
+//	:param name: description
+//	:type name: str
+//	:returns: description
+//	:raises ValueError: description
+func parseSphinx(summary string, lines []string) Parsed {
+	p := Parsed{Summary: summary}
+	params := map[string]*Param{}
+	var order []string
+	var description []string
+	var example []string
+	inDescription := true
+
+	paramFor := func(name string) *Param {
+		if params[name] == nil {
+			params[name] = &Param{Name: name}
+			order = append(order, name)
+		}
+		return params[name]
+	}
+
+	for _, line := range lines {
+		switch {
+		case sphinxParam.MatchString(line):
+			m := sphinxParam.FindStringSubmatch(line)
+			paramFor(m[2]).Description = strings.TrimSpace(m[3])
+			inDescription = false
+		case sphinxType.MatchString(line):
+			// Type annotations on the docstring are redundant with the signature's own
+			// type hints (see the parameter-extraction change); nothing further to record.
+			inDescription = false
+		case sphinxReturns.MatchString(line):
+			m := sphinxReturns.FindStringSubmatch(line)
+			p.Returns = strings.TrimSpace(m[1])
+			inDescription = false
+		case sphinxRaises.MatchString(line):
+			m := sphinxRaises.FindStringSubmatch(line)
+			p.Raises = append(p.Raises, Raises{Type: m[1], Description: strings.TrimSpace(m[2])})
+			inDescription = false
+		case doctestPrompt.MatchString(line):
+			example = append(example, line)
+		default:
+			if inDescription {
+				description = append(description, line)
+			} else if strings.TrimSpace(line) != "" {
+				example = append(example, line)
+			}
+		}
+	}
+
+	for _, name := range order {
+		p.Params = append(p.Params, *params[name])
+	}
+	p.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	p.Examples = append(p.Examples, collectExamples(example)...)
+	return p
+}
+
+// ParseDoctest splits text into its doctest examples the same way Parse does for a
+// docstring's Examples: section. It's exported so that other indexers can reuse the exact
+// same ">>> " handling outside of a docstring — e.g. the Markdown indexer, for fenced code
+// blocks tagged ```pycon, so that Python examples embedded in .md files are linkable too.
+func ParseDoctest(text string) []Example {
+	return collectExamples(strings.Split(text, "\n"))
+}
+
+// collectExamples groups consecutive ">>> "/"... " doctest lines into Example blocks,
+// keeping surrounding prose as separate, non-doctest Examples.
+func collectExamples(lines []string) []Example {
+	var out []Example
+	var doctest, prose []string
+	var promptIndent int
+
+	flushDoctest := func() {
+		if len(doctest) > 0 {
+			out = append(out, Example{Text: strings.Join(doctest, "\n"), IsDoctest: true})
+			doctest = nil
+		}
+	}
+	flushProse := func() {
+		if text := strings.TrimSpace(strings.Join(prose, "\n")); text != "" {
+			out = append(out, Example{Text: text})
+		}
+		prose = nil
+	}
+
+	for _, line := range lines {
+		if m := doctestPrompt.FindStringSubmatch(line); m != nil {
+			flushProse()
+			promptIndent = len(m[1])
+			doctest = append(doctest, ">>> "+m[2])
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if len(doctest) > 0 && trimmed != "" && (strings.HasPrefix(trimmed, "...") || indent >= promptIndent) {
+			// A continuation line or expected-output line indented at least as much as
+			// the ">>> " prompt that started this block belongs to that same doctest,
+			// regardless of what characters it contains (expected output can itself
+			// contain ":", e.g. a dict repr or an "Error: ..." message).
+			doctest = append(doctest, line)
+			continue
+		}
+		flushDoctest()
+		prose = append(prose, line)
+	}
+	flushDoctest()
+	flushProse()
+	return out
+}
+
+// splitDedent splits s into lines and strips the common leading whitespace, the way
+// Python's own inspect.cleandoc/textwrap.dedent treat docstrings.
+func splitDedent(s string) []string {
+	lines := strings.Split(strings.ReplaceAll(s, "\t", "    "), "\n")
+
+	minIndent := -1
+	for _, line := range lines[min(1, len(lines)):] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if minIndent == -1 || indent < minIndent {
+			minIndent = indent
+		}
+	}
+	if minIndent <= 0 {
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " ")
+		}
+		return lines
+	}
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if i == 0 || len(line) < minIndent {
+			out[i] = strings.TrimRight(strings.TrimLeft(line, " "), " ")
+			continue
+		}
+		out[i] = strings.TrimRight(line[minIndent:], " ")
+	}
+	return out
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,171 @@
+package docstring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGoogle(t *testing.T) {
+	raw := `Summary line.
+
+Longer description
+spanning two lines.
+
+Args:
+name (str): the thing's name.
+count: how many, continued
+    onto a second line.
+
+Returns:
+bool: whether it worked.
+
+Raises:
+ValueError: if name is empty.
+
+Examples:
+>>> do_it("x")
+True
+`
+	got := Parse(raw)
+
+	if got.Summary != "Summary line." {
+		t.Errorf("Summary = %q", got.Summary)
+	}
+	if got.Description != "Longer description\nspanning two lines." {
+		t.Errorf("Description = %q", got.Description)
+	}
+	wantParams := []Param{
+		{Name: "name", Description: "the thing's name."},
+		{Name: "count", Description: "how many, continued onto a second line."},
+	}
+	if !reflect.DeepEqual(got.Params, wantParams) {
+		t.Errorf("Params = %+v, want %+v", got.Params, wantParams)
+	}
+	if got.Returns != "bool: whether it worked." {
+		t.Errorf("Returns = %q", got.Returns)
+	}
+	wantRaises := []Raises{{Type: "ValueError", Description: "if name is empty."}}
+	if !reflect.DeepEqual(got.Raises, wantRaises) {
+		t.Errorf("Raises = %+v, want %+v", got.Raises, wantRaises)
+	}
+	if len(got.Examples) != 1 || !got.Examples[0].IsDoctest {
+		t.Fatalf("Examples = %+v, want one doctest example", got.Examples)
+	}
+	if got.Examples[0].Text != ">>> do_it(\"x\")\nTrue" {
+		t.Errorf("Examples[0].Text = %q", got.Examples[0].Text)
+	}
+}
+
+func TestParseNumPy(t *testing.T) {
+	raw := `Summary line.
+
+Parameters
+----------
+name : str
+the thing's name.
+count : int
+how many.
+
+Returns
+-------
+whether it worked.
+`
+	got := Parse(raw)
+
+	if got.Summary != "Summary line." {
+		t.Errorf("Summary = %q", got.Summary)
+	}
+	wantParams := []Param{
+		{Name: "name", Description: "the thing's name."},
+		{Name: "count", Description: "how many."},
+	}
+	if !reflect.DeepEqual(got.Params, wantParams) {
+		t.Errorf("Params = %+v, want %+v", got.Params, wantParams)
+	}
+	if got.Returns != "whether it worked." {
+		t.Errorf("Returns = %q", got.Returns)
+	}
+}
+
+func TestParseSphinx(t *testing.T) {
+	raw := `Summary line.
+
+:param name: the thing's name.
+:type name: str
+:returns: whether it worked.
+:raises ValueError: if name is empty.
+`
+	got := Parse(raw)
+
+	if got.Summary != "Summary line." {
+		t.Errorf("Summary = %q", got.Summary)
+	}
+	wantParams := []Param{{Name: "name", Description: "the thing's name."}}
+	if !reflect.DeepEqual(got.Params, wantParams) {
+		t.Errorf("Params = %+v, want %+v", got.Params, wantParams)
+	}
+	if got.Returns != "whether it worked." {
+		t.Errorf("Returns = %q", got.Returns)
+	}
+	wantRaises := []Raises{{Type: "ValueError", Description: "if name is empty."}}
+	if !reflect.DeepEqual(got.Raises, wantRaises) {
+		t.Errorf("Raises = %+v, want %+v", got.Raises, wantRaises)
+	}
+}
+
+func TestParseDoctest(t *testing.T) {
+	text := `>>> 1 + 1
+2
+
+Some prose about the example above.`
+
+	examples := ParseDoctest(text)
+	if len(examples) != 2 {
+		t.Fatalf("ParseDoctest returned %d examples, want 2: %+v", len(examples), examples)
+	}
+	if !examples[0].IsDoctest || examples[0].Text != ">>> 1 + 1\n2" {
+		t.Errorf("examples[0] = %+v", examples[0])
+	}
+	if examples[1].IsDoctest || examples[1].Text != "Some prose about the example above." {
+		t.Errorf("examples[1] = %+v", examples[1])
+	}
+}
+
+func TestHasNumPyHeadersDoesNotMatchGoogleStyle(t *testing.T) {
+	lines := splitDedent(`Args:
+    name (str): the thing's name.
+`)
+	if hasNumPyHeaders(lines) {
+		t.Error("hasNumPyHeaders reported a Google-style Args: block as NumPy")
+	}
+}
+
+func TestParseDoctestOutputContainingColon(t *testing.T) {
+	text := `>>> d
+{'a': 1}
+
+Some prose about the example above.`
+
+	examples := ParseDoctest(text)
+	if len(examples) != 2 {
+		t.Fatalf("ParseDoctest returned %d examples, want 2: %+v", len(examples), examples)
+	}
+	if !examples[0].IsDoctest || examples[0].Text != ">>> d\n{'a': 1}" {
+		t.Errorf("examples[0] = %+v, want output line kept in the doctest block", examples[0])
+	}
+	if examples[1].IsDoctest || examples[1].Text != "Some prose about the example above." {
+		t.Errorf("examples[1] = %+v", examples[1])
+	}
+}
+
+func TestParseNumPyParamDescriptionContainingColon(t *testing.T) {
+	lines := []string{
+		"mapping : dict",
+		"a mapping of key: value",
+	}
+	got := parseNumPyParams(lines)
+	want := paramList{{Name: "mapping", Description: "a mapping of key: value"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNumPyParams = %+v, want %+v", got, want)
+	}
+}
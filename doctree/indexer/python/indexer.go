@@ -3,6 +3,7 @@ package python
 
 import (
 	"context"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -12,6 +13,7 @@ import (
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/python"
 	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/indexer/python/docstring"
 	"github.com/sourcegraph/doctree/doctree/schema"
 )
 
@@ -48,6 +50,7 @@ func (i *pythonIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index
 	bytes := 0
 	mods := map[string]moduleInfo{}
 	functionsByMod := map[string][]schema.Section{}
+	classesByMod := map[string][]schema.Section{}
 	for _, path := range sources {
 		if strings.Contains(path, "test_") || strings.Contains(path, "_test") || strings.Contains(path, "tests") {
 			continue
@@ -114,17 +117,18 @@ func (i *pythonIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index
 			}
 		}
 
-		// Function definitions
+		// Module-level __all__ = [...] (or (...)) assignment. When present it is
+		// authoritative for visibility: names listed are public regardless of underscore
+		// prefix, and names not listed are private even without one.
+		var exportedNames map[string]bool
 		{
 			query, err := sitter.NewQuery([]byte(`
-			(
-				module
-				(
-				function_definition
-					name: (identifier) @func_name
-					parameters: (parameters) @func_params
-					return_type: (type)? @func_result
-					body: (block . (expression_statement (string) @func_docs)?)
+			(module
+				(expression_statement
+					(assignment
+						left: (identifier) @all_name
+						right: [(list) (tuple)] @all_list
+					)
 				)
 			)
 			`), python.GetLanguage())
@@ -143,32 +147,134 @@ func (i *pythonIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index
 					break
 				}
 				captures := getCaptures(query, match)
+				if firstCaptureContentOr(content, captures["all_name"], "") != "__all__" {
+					continue
+				}
 
-				funcDocs := joinCaptures(content, captures["func_docs"], "\n")
-				funcDocs = sanitizeDocs(funcDocs)
-				funcName := firstCaptureContentOr(content, captures["func_name"], "")
-				funcParams := firstCaptureContentOr(content, captures["func_params"], "")
-				funcResult := firstCaptureContentOr(content, captures["func_result"], "")
+				exportedNames = map[string]bool{}
+				list := firstCaptureNode(captures["all_list"])
+				if list == nil {
+					continue
+				}
+				for i := 0; i < int(list.NamedChildCount()); i++ {
+					if item := list.NamedChild(i); item.Type() == "string" {
+						exportedNames[stringLiteralValue(content, item)] = true
+					}
+				}
+			}
+		}
 
-				if len(funcName) > 0 && funcName[0] == '_' && funcName[len(funcName)-1] != '_' {
-					continue // unexported (private function)
+		// Top-level function definitions, including decorated ones (e.g. @staticmethod).
+		{
+			query, err := sitter.NewQuery([]byte(`
+			(module
+				[
+					(function_definition
+						name: (identifier) @func_name
+						parameters: (parameters) @func_params
+						return_type: (type)? @func_result
+						body: (block . (expression_statement (string) @func_docs)?)
+					) @func_def
+					(decorated_definition
+						(decorator)+ @func_decorator
+						definition: (function_definition
+							name: (identifier) @func_name
+							parameters: (parameters) @func_params
+							return_type: (type)? @func_result
+							body: (block . (expression_statement (string) @func_docs)?)
+						) @func_def
+					)
+				]
+			)
+			`), python.GetLanguage())
+			if err != nil {
+				return nil, errors.Wrap(err, "NewQuery")
+			}
+			defer query.Close()
+
+			cursor := sitter.NewQueryCursor()
+			defer cursor.Close()
+			cursor.Exec(query, n)
+
+			for {
+				match, ok := cursor.NextMatch()
+				if !ok {
+					break
 				}
+				captures := getCaptures(query, match)
 
-				funcLabel := schema.Markdown("def " + funcName + funcParams)
-				if funcResult != "" {
-					funcLabel = funcLabel + schema.Markdown(" -> "+funcResult)
+				funcName := firstCaptureContentOr(content, captures["func_name"], "")
+				if !isExported(funcName, exportedNames) {
+					continue // unexported (private function)
 				}
+
 				funcs := functionsByMod[modName]
-				funcs = append(funcs, schema.Section{
-					ID:         funcName,
-					ShortLabel: funcName,
-					Label:      funcLabel,
-					Detail:     schema.Markdown(funcDocs),
-					SearchKey:  []string{modName, ".", funcName},
-				})
+				funcs = append(funcs, functionSection(content, captures, []string{modName, ".", funcName}))
 				functionsByMod[modName] = funcs
 			}
 		}
+
+		// Class definitions, including decorated ones (e.g. @dataclass), with their methods.
+		{
+			query, err := sitter.NewQuery([]byte(`
+			(module
+				[
+					(class_definition
+						name: (identifier) @class_name
+						superclasses: (argument_list)? @class_bases
+						body: (block . (expression_statement (string) @class_docs)?) @class_body
+					) @class_def
+					(decorated_definition
+						(decorator)+ @class_decorator
+						definition: (class_definition
+							name: (identifier) @class_name
+							superclasses: (argument_list)? @class_bases
+							body: (block . (expression_statement (string) @class_docs)?) @class_body
+						) @class_def
+					)
+				]
+			)
+			`), python.GetLanguage())
+			if err != nil {
+				return nil, errors.Wrap(err, "NewQuery")
+			}
+			defer query.Close()
+
+			cursor := sitter.NewQueryCursor()
+			defer cursor.Close()
+			cursor.Exec(query, n)
+
+			for {
+				match, ok := cursor.NextMatch()
+				if !ok {
+					break
+				}
+				captures := getCaptures(query, match)
+
+				className := firstCaptureContentOr(content, captures["class_name"], "")
+				if !isExported(className, exportedNames) {
+					continue // unexported (private class)
+				}
+
+				classDocs := joinCaptures(content, captures["class_docs"], "\n")
+				classDocs = sanitizeDocs(classDocs)
+				classBases := firstCaptureContentOr(content, captures["class_bases"], "")
+				classLabel := schema.Markdown(decoratorPrefix(content, captures["class_decorator"]) + "class " + className + classBases)
+				body := firstCaptureNode(captures["class_body"])
+
+				classes := classesByMod[modName]
+				classes = append(classes, schema.Section{
+					ID:         className,
+					ShortLabel: className,
+					Label:      classLabel,
+					Detail:     schema.Markdown(classDocs),
+					SearchKey:  []string{modName, ".", className},
+					Category:   true,
+					Children:   methodSections(content, body, modName, className, exportedNames != nil && exportedNames[className]),
+				})
+				classesByMod[modName] = classes
+			}
+		}
 	}
 
 	var pages []schema.Page
@@ -182,12 +288,21 @@ func (i *pythonIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index
 			Children:   functionsByMod[modName],
 		}
 
+		classesSection := schema.Section{
+			ID:         "class",
+			ShortLabel: "class",
+			Label:      "Classes",
+			SearchKey:  []string{},
+			Category:   true,
+			Children:   classesByMod[modName],
+		}
+
 		pages = append(pages, schema.Page{
 			Path:      moduleInfo.path,
 			Title:     "Module " + modName,
 			Detail:    schema.Markdown(moduleInfo.docs),
 			SearchKey: []string{modName},
-			Sections:  []schema.Section{functionsSection},
+			Sections:  []schema.Section{classesSection, functionsSection},
 		})
 	}
 
@@ -241,3 +356,274 @@ func getCaptures(q *sitter.Query, m *sitter.QueryMatch) map[string][]*sitter.Nod
 	}
 	return captures
 }
+
+// isUnexported reports whether name is a private Python identifier (leading underscore),
+// with an exception for dunder names like __init__ and __repr__.
+func isUnexported(name string) bool {
+	return len(name) > 0 && name[0] == '_' && name[len(name)-1] != '_'
+}
+
+// isExported reports whether name should be treated as public. If the module defines
+// __all__, that list is authoritative; otherwise visibility falls back to the
+// underscore-prefix convention.
+func isExported(name string, all map[string]bool) bool {
+	if all != nil {
+		return all[name]
+	}
+	return !isUnexported(name)
+}
+
+// firstCaptureNode returns the first node in captures, or nil if there isn't one.
+func firstCaptureNode(captures []*sitter.Node) *sitter.Node {
+	if len(captures) > 0 {
+		return captures[0]
+	}
+	return nil
+}
+
+// stringLiteralValue strips the surrounding quotes from a Python string node's content.
+func stringLiteralValue(content []byte, n *sitter.Node) string {
+	return strings.Trim(n.Content(content), `"'`)
+}
+
+// extractParameters walks a parameters node and records each parameter's name, type
+// annotation, and default value, so the UI can render formatted signatures and users can
+// search for a type name (e.g. "List[str]" or "Optional[Path]").
+func extractParameters(content []byte, params *sitter.Node) []schema.Parameter {
+	if params == nil {
+		return nil
+	}
+
+	var out []schema.Parameter
+	for i := 0; i < int(params.NamedChildCount()); i++ {
+		child := params.NamedChild(i)
+		switch child.Type() {
+		case "typed_parameter":
+			out = append(out, schema.Parameter{
+				Name: contentOf(content, child.NamedChild(0)),
+				Type: contentOf(content, child.ChildByFieldName("type")),
+			})
+		case "default_parameter":
+			out = append(out, schema.Parameter{
+				Name:    contentOf(content, child.ChildByFieldName("name")),
+				Default: contentOf(content, child.ChildByFieldName("value")),
+			})
+		case "typed_default_parameter":
+			out = append(out, schema.Parameter{
+				Name:    contentOf(content, child.ChildByFieldName("name")),
+				Type:    contentOf(content, child.ChildByFieldName("type")),
+				Default: contentOf(content, child.ChildByFieldName("value")),
+			})
+		default:
+			// identifier, list_splat_pattern (*args), dictionary_splat_pattern (**kwargs), etc.
+			out = append(out, schema.Parameter{Name: child.Content(content)})
+		}
+	}
+	return out
+}
+
+// parameterSearchKeys returns the non-empty type annotations from params.
+func parameterSearchKeys(params []schema.Parameter) []string {
+	var keys []string
+	for _, p := range params {
+		if p.Type != "" {
+			keys = append(keys, p.Type)
+		}
+	}
+	return keys
+}
+
+// decoratorPrefix renders captured @decorator nodes so they can be prepended to a
+// function/class label, e.g. "@property def name(self) -> str".
+func decoratorPrefix(content []byte, decorators []*sitter.Node) string {
+	if len(decorators) == 0 {
+		return ""
+	}
+	return joinCaptures(content, decorators, " ") + " "
+}
+
+// functionSection builds a schema.Section for a top-level function_definition query match,
+// honoring any captured decorators.
+func functionSection(content []byte, captures map[string][]*sitter.Node, searchKey []string) schema.Section {
+	funcName := firstCaptureContentOr(content, captures["func_name"], "")
+	funcParams := firstCaptureContentOr(content, captures["func_params"], "")
+	funcResult := firstCaptureContentOr(content, captures["func_result"], "")
+	rawDocs := sanitizeDocs(joinCaptures(content, captures["func_docs"], "\n"))
+
+	detail, paramDocs, examples := renderDocs(rawDocs)
+	params := extractParameters(content, firstCaptureNode(captures["func_params"]))
+	applyParamDocs(params, paramDocs)
+
+	label := schema.Markdown(decoratorPrefix(content, captures["func_decorator"]) + "def " + funcName + funcParams)
+	if funcResult != "" {
+		label = label + schema.Markdown(" -> "+funcResult)
+	}
+
+	return schema.Section{
+		ID:         funcName,
+		ShortLabel: funcName,
+		Label:      label,
+		Detail:     schema.Markdown(detail),
+		Parameters: params,
+		Children:   examples,
+		SearchKey:  append(searchKey, parameterSearchKeys(params)...),
+	}
+}
+
+// renderDocs parses a sanitized (quote-stripped) docstring into a Markdown detail blob,
+// a name -> description map for documented parameters, and doctest Examples rendered as
+// child sections (mirroring how Go's godoc surfaces Example* functions).
+func renderDocs(raw string) (detail string, paramDocs map[string]string, examples []schema.Section) {
+	if strings.TrimSpace(raw) == "" {
+		return "", nil, nil
+	}
+	parsed := docstring.Parse(raw)
+
+	var b strings.Builder
+	b.WriteString(parsed.Summary)
+	if parsed.Description != "" {
+		fmt.Fprintf(&b, "\n\n%s", parsed.Description)
+	}
+	if parsed.Returns != "" {
+		fmt.Fprintf(&b, "\n\n**Returns:** %s", parsed.Returns)
+	}
+	for _, r := range parsed.Raises {
+		fmt.Fprintf(&b, "\n\n**Raises %s:** %s", r.Type, r.Description)
+	}
+	if parsed.SeeAlso != "" {
+		fmt.Fprintf(&b, "\n\n**See also:** %s", parsed.SeeAlso)
+	}
+
+	if len(parsed.Params) > 0 {
+		paramDocs = make(map[string]string, len(parsed.Params))
+		for _, p := range parsed.Params {
+			paramDocs[p.Name] = p.Description
+		}
+	}
+
+	doctestNum := 0
+	for _, ex := range parsed.Examples {
+		if !ex.IsDoctest {
+			// Prose that accompanied an Examples: section but used no ">>> " prompts;
+			// keep it in the detail blob rather than silently dropping it.
+			fmt.Fprintf(&b, "\n\n%s", ex.Text)
+			continue
+		}
+		doctestNum++
+		examples = append(examples, schema.Section{
+			ID:         fmt.Sprintf("example-%d", doctestNum),
+			ShortLabel: "Example",
+			Label:      "Example",
+			Detail:     schema.Markdown("```pycon\n" + ex.Text + "\n```"),
+		})
+	}
+	return b.String(), paramDocs, examples
+}
+
+// applyParamDocs fills in each parameter's Description from docs, by name, leaving
+// undocumented parameters untouched.
+func applyParamDocs(params []schema.Parameter, docs map[string]string) {
+	for i := range params {
+		if doc, ok := docs[params[i].Name]; ok {
+			params[i].Description = doc
+		}
+	}
+}
+
+// methodInfo holds the pieces of a (possibly decorated) method's function_definition node.
+type methodInfo struct {
+	name, params, result, detail string
+	parameters                   []schema.Parameter
+	examples                     []schema.Section
+	decorators                   []*sitter.Node
+}
+
+func contentOf(content []byte, n *sitter.Node) string {
+	if n == nil {
+		return ""
+	}
+	return n.Content(content)
+}
+
+func describeMethodNode(content []byte, def *sitter.Node, decorators []*sitter.Node) methodInfo {
+	info := methodInfo{
+		name:       contentOf(content, def.ChildByFieldName("name")),
+		params:     contentOf(content, def.ChildByFieldName("parameters")),
+		result:     contentOf(content, def.ChildByFieldName("return_type")),
+		parameters: extractParameters(content, def.ChildByFieldName("parameters")),
+		decorators: decorators,
+	}
+
+	var rawDocs string
+	if body := def.ChildByFieldName("body"); body != nil && body.NamedChildCount() > 0 {
+		if stmt := body.NamedChild(0); stmt.Type() == "expression_statement" && stmt.NamedChildCount() > 0 {
+			if docNode := stmt.NamedChild(0); docNode.Type() == "string" {
+				rawDocs = sanitizeDocs(docNode.Content(content))
+			}
+		}
+	}
+
+	var paramDocs map[string]string
+	info.detail, paramDocs, info.examples = renderDocs(rawDocs)
+	applyParamDocs(info.parameters, paramDocs)
+
+	return info
+}
+
+// methodSections extracts the methods declared directly in a class body block (including
+// decorated ones, e.g. @staticmethod/@classmethod/@property), skipping private names —
+// unless classExported is set, meaning the class itself was explicitly named in the
+// module's __all__, in which case every one of its methods is documented regardless of
+// the underscore-prefix convention.
+func methodSections(content []byte, body *sitter.Node, modName, className string, classExported bool) []schema.Section {
+	if body == nil {
+		return nil
+	}
+
+	var methods []schema.Section
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		child := body.NamedChild(i)
+
+		var def *sitter.Node
+		var decorators []*sitter.Node
+		switch child.Type() {
+		case "function_definition":
+			def = child
+		case "decorated_definition":
+			inner := child.ChildByFieldName("definition")
+			if inner == nil || inner.Type() != "function_definition" {
+				continue
+			}
+			def = inner
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if d := child.NamedChild(j); d.Type() == "decorator" {
+					decorators = append(decorators, d)
+				}
+			}
+		default:
+			continue
+		}
+
+		info := describeMethodNode(content, def, decorators)
+		if !classExported && isUnexported(info.name) {
+			continue // unexported (private method)
+		}
+
+		label := schema.Markdown(decoratorPrefix(content, info.decorators) + "def " + info.name + info.params)
+		if info.result != "" {
+			label = label + schema.Markdown(" -> "+info.result)
+		}
+
+		searchKey := append([]string{modName, ".", className, ".", info.name}, parameterSearchKeys(info.parameters)...)
+		methods = append(methods, schema.Section{
+			ID:         info.name,
+			ShortLabel: info.name,
+			Label:      label,
+			Detail:     schema.Markdown(info.detail),
+			Parameters: info.parameters,
+			Children:   info.examples,
+			SearchKey:  searchKey,
+		})
+	}
+	return methods
+}
@@ -0,0 +1,187 @@
+package python
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+// indexSource writes src to a single-file temp project and runs it through IndexDir,
+// returning the one schema.Page produced for it.
+func indexSource(t *testing.T, src string) schema.Page {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mod.py"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := (&pythonIndexer{}).IndexDir(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("IndexDir: %v", err)
+	}
+	if len(idx.Libraries) != 1 || len(idx.Libraries[0].Pages) != 1 {
+		t.Fatalf("IndexDir returned %d libraries; want 1 library with 1 page", len(idx.Libraries))
+	}
+	return idx.Libraries[0].Pages[0]
+}
+
+// findByID returns the section with the given ID among sections and their children, or
+// nil if there isn't one.
+func findByID(sections []schema.Section, id string) *schema.Section {
+	for i := range sections {
+		if sections[i].ID == id {
+			return &sections[i]
+		}
+		if found := findByID(sections[i].Children, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func TestIndexDirClassWithMethods(t *testing.T) {
+	page := indexSource(t, `
+class Greeter:
+    """Greets people."""
+
+    def greet(self, name):
+        """Say hello."""
+        return "hello " + name
+
+    def _internal(self):
+        pass
+`)
+
+	class := findByID(page.Sections, "Greeter")
+	if class == nil {
+		t.Fatal("Greeter class section not found")
+	}
+	if string(class.Detail) != "Greets people." {
+		t.Errorf("class Detail = %q", class.Detail)
+	}
+
+	greet := findByID(class.Children, "greet")
+	if greet == nil {
+		t.Fatal("greet method section not found")
+	}
+	if string(greet.Label) != "def greet(self, name)" {
+		t.Errorf("greet Label = %q", greet.Label)
+	}
+
+	if findByID(class.Children, "_internal") != nil {
+		t.Error("_internal method should be excluded as unexported")
+	}
+}
+
+func TestIndexDirDecoratedClassAndMethods(t *testing.T) {
+	page := indexSource(t, `
+@dataclass
+class Point:
+    """A point."""
+
+    @property
+    def magnitude(self):
+        return 0
+
+    @staticmethod
+    def origin():
+        return Point()
+`)
+
+	class := findByID(page.Sections, "Point")
+	if class == nil {
+		t.Fatal("Point class section not found")
+	}
+	if !strings.HasPrefix(string(class.Label), "@dataclass class Point") {
+		t.Errorf("class Label = %q, want @dataclass prefix", class.Label)
+	}
+
+	magnitude := findByID(class.Children, "magnitude")
+	if magnitude == nil || !strings.HasPrefix(string(magnitude.Label), "@property def magnitude") {
+		t.Errorf("magnitude Label = %+v, want @property prefix", magnitude)
+	}
+
+	origin := findByID(class.Children, "origin")
+	if origin == nil || !strings.HasPrefix(string(origin.Label), "@staticmethod def origin") {
+		t.Errorf("origin Label = %+v, want @staticmethod prefix", origin)
+	}
+}
+
+func TestIndexDirParameterTypesAndDefaults(t *testing.T) {
+	page := indexSource(t, `
+def do_it(name: str, count=1, limit: int = 10):
+    pass
+`)
+
+	fn := findByID(page.Sections, "do_it")
+	if fn == nil {
+		t.Fatal("do_it function section not found")
+	}
+	want := []schema.Parameter{
+		{Name: "name", Type: "str"},
+		{Name: "count", Default: "1"},
+		{Name: "limit", Type: "int", Default: "10"},
+	}
+	if len(fn.Parameters) != len(want) {
+		t.Fatalf("Parameters = %+v, want %+v", fn.Parameters, want)
+	}
+	for i, p := range want {
+		if fn.Parameters[i] != p {
+			t.Errorf("Parameters[%d] = %+v, want %+v", i, fn.Parameters[i], p)
+		}
+	}
+}
+
+func TestIndexDirAllListOverridesVisibility(t *testing.T) {
+	page := indexSource(t, `
+__all__ = ["_Special", "public_func"]
+
+class _Special:
+    """Exported despite the underscore prefix."""
+
+class Hidden:
+    """Not exported; not in __all__."""
+
+def public_func():
+    pass
+
+def _private_func():
+    pass
+`)
+
+	if findByID(page.Sections, "_Special") == nil {
+		t.Error("_Special should be exported via __all__")
+	}
+	if findByID(page.Sections, "Hidden") != nil {
+		t.Error("Hidden should be excluded: not listed in __all__")
+	}
+	if findByID(page.Sections, "public_func") == nil {
+		t.Error("public_func should be exported via __all__")
+	}
+	if findByID(page.Sections, "_private_func") != nil {
+		t.Error("_private_func should be excluded: not listed in __all__")
+	}
+}
+
+func TestIndexDirAllTupleOverridesVisibility(t *testing.T) {
+	page := indexSource(t, `
+__all__ = ("_Special",)
+
+class _Special:
+    """Exported despite the underscore prefix."""
+
+class Hidden:
+    """Not exported; not in __all__."""
+`)
+
+	if findByID(page.Sections, "_Special") == nil {
+		t.Error("_Special should be exported via a tuple __all__")
+	}
+	if findByID(page.Sections, "Hidden") != nil {
+		t.Error("Hidden should be excluded: not listed in __all__")
+	}
+}
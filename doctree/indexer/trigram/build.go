@@ -0,0 +1,60 @@
+package trigram
+
+import (
+	"strings"
+
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+// Documents returns every document currently held by idx, in docID order.
+func (idx *Index) Documents() []Document {
+	return idx.docs
+}
+
+// BuildProjectIndex builds a trigram Index from every schema.Page and schema.Section in
+// idx (recursing into Section.Children), ready to be merged into a project's index and
+// written to disk with Write.
+func BuildProjectIndex(project string, idx *schema.Index) *Index {
+	t := New()
+	for _, lib := range idx.Libraries {
+		for _, page := range lib.Pages {
+			addPage(t, project, string(idx.Language), page)
+		}
+	}
+	return t
+}
+
+func addPage(t *Index, project, language string, page schema.Page) {
+	t.Add(Document{
+		Project:  project,
+		Language: language,
+		PagePath: page.Path,
+		Label:    string(page.Title),
+		Content:  pageContent(page),
+	})
+	for _, section := range page.Sections {
+		addSection(t, project, language, page.Path, section)
+	}
+}
+
+func addSection(t *Index, project, language, pagePath string, section schema.Section) {
+	t.Add(Document{
+		Project:   project,
+		Language:  language,
+		PagePath:  pagePath,
+		SectionID: section.ID,
+		Label:     string(section.Label),
+		Content:   sectionContent(section),
+	})
+	for _, child := range section.Children {
+		addSection(t, project, language, pagePath, child)
+	}
+}
+
+func pageContent(page schema.Page) string {
+	return strings.Join(page.SearchKey, " ") + " " + string(page.Title) + " " + string(page.Detail)
+}
+
+func sectionContent(section schema.Section) string {
+	return strings.Join(section.SearchKey, " ") + " " + string(section.Label) + " " + string(section.Detail)
+}
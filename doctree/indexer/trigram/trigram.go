@@ -0,0 +1,390 @@
+// Package trigram implements a small, zoekt-style persistent trigram index used by the
+// `search` and `lsp` subcommands to avoid indexer.Search's linear scan over every indexed
+// doctree.Section/Page, once `doctree reindex -trigrams` has built one for a project.
+//
+// For each project we keep a single on-disk index file at
+// <data-dir>/index/trigrams/<project>.idx holding:
+//
+//   - a document table (the searchable text for every schema.Section/schema.Page, so a
+//     trigram hit can be verified with a direct substring check and a snippet can be
+//     rendered without re-reading the source JSON)
+//   - a posting list per trigram: the sorted, delta-encoded docIDs whose document contains
+//     that trigram
+//
+// Re-indexing a project rebuilds that project's single file from scratch (docIDs are only
+// ever increasing within that one build); no other project's index is touched.
+package trigram
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// fileMagic identifies a trigram index file, and indexVersion lets us reject indexes
+// written by an incompatible version of this package.
+const (
+	fileMagic    = "DTTG"
+	indexVersion = 1
+)
+
+// Document is a single unit of searchable content: one schema.Section or schema.Page.
+type Document struct {
+	Project   string // project name, e.g. "github.com/foo/bar"
+	Language  string // schema.Language the document belongs to
+	PagePath  string // source page path, e.g. "internal/foo/foo.go"
+	SectionID string // schema.Section.ID, or "" if this document is a schema.Page itself
+	Label     string // schema.Section.Label / schema.Page.Title
+	Content   string // SearchKey + Label + Detail, concatenated; trigram-extracted and stored verbatim
+}
+
+// Path returns the path for an on-disk project index.
+func Path(dataDir, project string) string {
+	return filepath.Join(dataDir, "trigrams", project+".idx")
+}
+
+// Index is a trigram index for a single project, loaded fully into memory. Indexes are
+// small enough (one per project) that this keeps both building and querying simple.
+type Index struct {
+	docs     []Document
+	postings map[string][]uint32 // trigram -> sorted, deduplicated docIDs
+}
+
+// New returns an empty index, ready to have documents added to it.
+func New() *Index {
+	return &Index{postings: map[string][]uint32{}}
+}
+
+// Add appends doc to the index, assigning it the next docID, and indexes its trigrams.
+// docIDs only ever grow within a single build: `doctree reindex -trigrams` builds a fresh
+// Index with New(), Adds every current document for the project, and Write()s it out,
+// replacing whatever was there before — it does not Open() and append to the prior file.
+func (idx *Index) Add(doc Document) {
+	docID := uint32(len(idx.docs))
+	idx.docs = append(idx.docs, doc)
+	for t := range Extract(doc.Content) {
+		idx.postings[t] = append(idx.postings[t], docID)
+	}
+}
+
+// Extract returns the set of overlapping, case-folded trigrams in s.
+func Extract(s string) map[string]struct{} {
+	s = strings.ToLower(s)
+	trigrams := map[string]struct{}{}
+	runes := []rune(s)
+	for i := 0; i+3 <= len(runes); i++ {
+		trigrams[string(runes[i:i+3])] = struct{}{}
+	}
+	return trigrams
+}
+
+// Candidates returns the docIDs whose document contains every trigram in query,
+// intersecting posting lists smallest-first (as zoekt does) so large/common trigrams
+// never need to be fully scanned.
+func (idx *Index) Candidates(trigrams []string) []uint32 {
+	if len(trigrams) == 0 {
+		return nil
+	}
+
+	lists := make([][]uint32, 0, len(trigrams))
+	for _, t := range trigrams {
+		posting, ok := idx.postings[t]
+		if !ok {
+			return nil // a required trigram doesn't occur anywhere; no document can match
+		}
+		lists = append(lists, posting)
+	}
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+
+	result := lists[0]
+	for _, list := range lists[1:] {
+		result = intersectSorted(result, list)
+		if len(result) == 0 {
+			break
+		}
+	}
+	return result
+}
+
+// Search looks up query as a plain substring: it extracts query's trigrams, finds
+// candidate documents, and verifies each with a direct substring check to rule out
+// trigram collisions.
+func (idx *Index) Search(query string) []Document {
+	needle := strings.ToLower(query)
+	trigrams := setKeys(Extract(needle))
+	if len(trigrams) == 0 {
+		// Query is shorter than a trigram; fall back to scanning every document.
+		return idx.verify(idx.allDocIDs(), needle)
+	}
+	return idx.verify(idx.Candidates(trigrams), needle)
+}
+
+// SearchRegexp looks up pattern as a regular expression. It extracts a required trigram
+// set from literal runs in the regex (so e.g. `Foo.*Bar` prefilters on "Foo" and "Bar"),
+// uses that to find candidates, then runs the real regex only against those.
+func (idx *Index) SearchRegexp(re *syntax.Regexp, match func(content string) bool) []Document {
+	required := RequiredTrigrams(re)
+
+	var candidates []uint32
+	if len(required) == 0 {
+		candidates = idx.allDocIDs()
+	} else {
+		candidates = idx.Candidates(required)
+	}
+
+	var out []Document
+	for _, id := range candidates {
+		doc := idx.docs[id]
+		if match(doc.Content) {
+			out = append(out, doc)
+		}
+	}
+	return out
+}
+
+// RequiredTrigrams extracts every trigram from literal runs of length >= 3 in re, which
+// can be used as a prefilter before running the full regex against candidate documents.
+func RequiredTrigrams(re *syntax.Regexp) []string {
+	re = re.Simplify()
+	trigrams := map[string]struct{}{}
+	collectLiteralTrigrams(re, trigrams)
+	return setKeys(trigrams)
+}
+
+func collectLiteralTrigrams(re *syntax.Regexp, into map[string]struct{}) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for t := range Extract(strings.ToLower(string(re.Rune))) {
+			into[t] = struct{}{}
+		}
+	case syntax.OpConcat, syntax.OpCapture, syntax.OpPlus, syntax.OpStar, syntax.OpQuest:
+		for _, sub := range re.Sub {
+			collectLiteralTrigrams(sub, into)
+		}
+	}
+	// Other ops (OpAlternate, OpAnyChar, OpCharClass, ...) don't guarantee a substring
+	// occurs in every match, so they contribute no required trigrams.
+}
+
+func (idx *Index) verify(candidates []uint32, needle string) []Document {
+	var out []Document
+	for _, id := range candidates {
+		doc := idx.docs[id]
+		if strings.Contains(strings.ToLower(doc.Content), needle) {
+			out = append(out, doc)
+		}
+	}
+	return out
+}
+
+func (idx *Index) allDocIDs() []uint32 {
+	ids := make([]uint32, len(idx.docs))
+	for i := range ids {
+		ids[i] = uint32(i)
+	}
+	return ids
+}
+
+func intersectSorted(a, b []uint32) []uint32 {
+	var out []uint32
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, a[i])
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return out
+}
+
+func setKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Write serializes idx to path, creating its parent directory if needed.
+func (idx *Index) Write(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "MkdirAll")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "Create")
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if _, err := w.WriteString(fileMagic); err != nil {
+		return errors.Wrap(err, "write magic")
+	}
+	if err := writeUvarint(w, indexVersion); err != nil {
+		return err
+	}
+
+	if err := writeUvarint(w, uint64(len(idx.docs))); err != nil {
+		return err
+	}
+	for _, doc := range idx.docs {
+		for _, field := range []string{doc.Project, doc.Language, doc.PagePath, doc.SectionID, doc.Label, doc.Content} {
+			if err := writeString(w, field); err != nil {
+				return err
+			}
+		}
+	}
+
+	trigrams := make([]string, 0, len(idx.postings))
+	for t := range idx.postings {
+		trigrams = append(trigrams, t)
+	}
+	sort.Strings(trigrams)
+
+	if err := writeUvarint(w, uint64(len(trigrams))); err != nil {
+		return err
+	}
+	for _, t := range trigrams {
+		if err := writeString(w, t); err != nil {
+			return err
+		}
+		posting := idx.postings[t]
+		sort.Slice(posting, func(i, j int) bool { return posting[i] < posting[j] })
+
+		if err := writeUvarint(w, uint64(len(posting))); err != nil {
+			return err
+		}
+		var prev uint32
+		for _, docID := range posting {
+			if err := writeUvarint(w, uint64(docID-prev)); err != nil {
+				return err
+			}
+			prev = docID
+		}
+	}
+
+	return w.Flush()
+}
+
+// Open reads a trigram index previously written by Write.
+func Open(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Open")
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	magic := make([]byte, len(fileMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, errors.Wrap(err, "read magic")
+	}
+	if string(magic) != fileMagic {
+		return nil, errors.New("trigram: not a trigram index file")
+	}
+	version, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read version")
+	}
+	if version != indexVersion {
+		return nil, errors.Errorf("trigram: unsupported index version %d", version)
+	}
+
+	idx := New()
+
+	numDocs, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read doc count")
+	}
+	idx.docs = make([]Document, numDocs)
+	for i := range idx.docs {
+		fields := make([]string, 6)
+		for j := range fields {
+			fields[j], err = readString(r)
+			if err != nil {
+				return nil, errors.Wrap(err, "read doc field")
+			}
+		}
+		idx.docs[i] = Document{
+			Project:   fields[0],
+			Language:  fields[1],
+			PagePath:  fields[2],
+			SectionID: fields[3],
+			Label:     fields[4],
+			Content:   fields[5],
+		}
+	}
+
+	numTrigrams, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read trigram count")
+	}
+	for i := uint64(0); i < numTrigrams; i++ {
+		trigram, err := readString(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "read trigram")
+		}
+		postingLen, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "read posting length")
+		}
+		posting := make([]uint32, postingLen)
+		var prev uint32
+		for j := range posting {
+			delta, err := binary.ReadUvarint(r)
+			if err != nil {
+				return nil, errors.Wrap(err, "read posting delta")
+			}
+			prev += uint32(delta)
+			posting[j] = prev
+		}
+		idx.postings[trigram] = posting
+	}
+
+	return idx, nil
+}
+
+func writeUvarint(w io.Writer, v uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	_, err := w.Write(buf[:n])
+	return errors.Wrap(err, "write varint")
+}
+
+func writeString(w io.Writer, s string) error {
+	if err := writeUvarint(w, uint64(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return errors.Wrap(err, "write string")
+}
+
+func readString(r io.Reader) (string, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		return "", errors.New("trigram: reader must implement io.ByteReader")
+	}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,150 @@
+package trigram
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp/syntax"
+	"sort"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"too short", "ab", nil},
+		{"exact trigram", "abc", []string{"abc"}},
+		{"case folded", "ABC", []string{"abc"}},
+		{"overlapping", "abcd", []string{"abc", "bcd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := setKeys(Extract(tt.in))
+			sort.Strings(got)
+			sort.Strings(tt.want)
+			if !equalStrings(got, tt.want) {
+				t.Fatalf("Extract(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// equalStrings compares two string slices, treating nil and empty as equal (setKeys never
+// returns nil, even for an empty set).
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := New()
+	idx.Add(Document{PagePath: "a.py", SectionID: "foo", Content: "def foo(bar): return bar"})
+	idx.Add(Document{PagePath: "b.py", SectionID: "baz", Content: "def baz(): pass"})
+	idx.Add(Document{PagePath: "c.py", SectionID: "qux", Content: "class Qux: pass"})
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string // SectionID of matching docs
+	}{
+		{"matches one", "bar", []string{"foo"}},
+		{"matches none", "nope", nil},
+		{"short query scans everything", "Q", []string{"qux"}},
+		{"case insensitive", "QUX", []string{"qux"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, doc := range idx.Search(tt.query) {
+				got = append(got, doc.SectionID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequiredTrigrams(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{"plain literal", "foobar", setKeys(Extract("foobar"))},
+		{"literal runs across wildcard", "foo.*bar", unionTrigrams("foo", "bar")},
+		{"no literal run long enough", "a.b", nil},
+		{"alternation contributes nothing", "foo|bar", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := syntax.Parse(tt.pattern, syntax.Perl)
+			if err != nil {
+				t.Fatalf("syntax.Parse: %v", err)
+			}
+			got := RequiredTrigrams(re)
+			sort.Strings(got)
+			sort.Strings(tt.want)
+			if !equalStrings(got, tt.want) {
+				t.Fatalf("RequiredTrigrams(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func unionTrigrams(strs ...string) []string {
+	set := map[string]struct{}{}
+	for _, s := range strs {
+		for t := range Extract(s) {
+			set[t] = struct{}{}
+		}
+	}
+	return setKeys(set)
+}
+
+func TestWriteOpenRoundTrip(t *testing.T) {
+	idx := New()
+	idx.Add(Document{Project: "p", Language: "python", PagePath: "a.py", SectionID: "foo", Label: "foo", Content: "def foo(): pass"})
+	idx.Add(Document{Project: "p", Language: "python", PagePath: "b.py", SectionID: "bar", Label: "bar", Content: "def bar(): pass"})
+
+	path := filepath.Join(t.TempDir(), "p.idx")
+	if err := idx.Write(path); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	got, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !reflect.DeepEqual(got.Documents(), idx.Documents()) {
+		t.Fatalf("round-tripped docs = %+v, want %+v", got.Documents(), idx.Documents())
+	}
+
+	var matched []string
+	for _, doc := range got.Search("bar") {
+		matched = append(matched, doc.SectionID)
+	}
+	if !reflect.DeepEqual(matched, []string{"bar"}) {
+		t.Fatalf("Search(%q) after round trip = %v, want [bar]", "bar", matched)
+	}
+}
+
+func TestOpenRejectsBadMagic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.idx")
+	if err := os.WriteFile(path, []byte("nope"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Fatal("Open: expected an error for a file with the wrong magic, got nil")
+	}
+}